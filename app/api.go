@@ -0,0 +1,121 @@
+/*
+Copyright © 2020 Luke Hinds <lhinds@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"time"
+
+	"github.com/google/trillian"
+	"github.com/projectrekor/rekor-server/app/submission"
+	"github.com/projectrekor/rekor-server/app/witness"
+)
+
+// addLeafDeadline bounds how long AddEntry waits for a submitted leaf to
+// be sequenced before giving up.
+const addLeafDeadline = 20 * time.Second
+
+// API holds the server-wide state behind rekor-server's HTTP handlers.
+type API struct {
+	logClient   *trillianclient
+	policy      submission.KeyPolicy
+	replication ReplicationConfig
+	replState   *replicationState
+	cosigner    *witness.Cosigner
+}
+
+// NewAPI wires up an API against the given Trillian log client and tree,
+// accepting submissions signed by a key permitted under policy and
+// cosigning tree heads with logKey.
+func NewAPI(client trillian.TrillianLogClient, tLogID int64, policy submission.KeyPolicy, logKey ed25519.PrivateKey) (*API, error) {
+	a := &API{
+		logClient:   serverInstance(client, tLogID),
+		policy:      policy,
+		replication: LoadReplicationConfig(),
+		replState:   &replicationState{},
+	}
+
+	witnesses, err := witness.LoadWitnessesFromConfig()
+	if err != nil {
+		return nil, err
+	}
+	a.cosigner = witness.NewCosigner(logKey, witnesses, a.witnessTreeHead)
+
+	return a, nil
+}
+
+// witnessTreeHead adapts replicatedTreeHead to the shape the witness
+// package signs over.
+func (a *API) witnessTreeHead() (witness.TreeHeadV1, error) {
+	sth, err := a.replicatedTreeHead()
+	if err != nil {
+		return witness.TreeHeadV1{}, err
+	}
+	return witness.TreeHeadV1{
+		TreeSize:  sth.TreeSize,
+		RootHash:  sth.RootHash,
+		Timestamp: sth.Timestamp,
+	}, nil
+}
+
+// StartCosigning launches the background loop that signs this log's tree
+// head and collects witness cosignatures on the given interval.
+func (a *API) StartCosigning(ctx context.Context, interval time.Duration) {
+	go a.cosigner.Start(ctx, interval)
+}
+
+// AddEntry verifies sub's signature against the configured key policy,
+// rejects it if its canonical leaf has already been logged, and only
+// then queues that leaf to Trillian and waits for it to be sequenced.
+// It refuses writes outright on a read-only secondary.
+//
+// Duplicate detection is a lookup against Trillian itself by the hash of
+// the whole canonical leaf (ShardHint, Checksum, Signature and KeyHash),
+// rather than a local cache, so it survives a server restart. A
+// resubmission of the same KeyHash+Checksum is rejected only when its
+// ShardHint and Signature also match the logged leaf; one with a
+// different ShardHint or Signature is logged as a new leaf. The lookup
+// and the queue below aren't one atomic
+// operation, so two concurrent resubmissions of the same statement can
+// both pass it; that's harmless since QueueLeaf's own AlreadyExists
+// handling in addLeaf makes the underlying write idempotent anyway.
+func (a *API) AddEntry(sub *submission.Submission) (sequenced bool, proof *trillian.Proof, err error) {
+	if a.replication.Role == RoleSecondary {
+		return false, nil, fmt.Errorf("server is a read-only secondary; submit entries to the primary at %s", a.replication.PrimaryURL)
+	}
+
+	if err := submission.Verify(sub, a.policy); err != nil {
+		return false, nil, err
+	}
+
+	leaf, err := sub.Leaf()
+	if err != nil {
+		return false, nil, err
+	}
+
+	dupResp, err := a.logClient.getLeaf(leaf, a.logClient.logID)
+	if err != nil {
+		return false, nil, err
+	}
+	if len(dupResp.getLeafResult.GetLeaves()) > 0 {
+		return false, nil, fmt.Errorf("duplicate submission for key %x checksum %x", sub.KeyHash, sub.Checksum)
+	}
+
+	return a.logClient.addLeaf(leaf, a.logClient.logID, addLeafDeadline)
+}
@@ -0,0 +1,153 @@
+/*
+Copyright © 2020 Luke Hinds <lhinds@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/projectrekor/rekor-server/logging"
+)
+
+// RegisterRoutes wires the log-auditing endpoints onto mux.
+func (a *API) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/v1/log/sth", a.GetSTHHandler)
+	mux.HandleFunc("/api/v1/log/proof/consistency", a.GetConsistencyProofHandler)
+	mux.HandleFunc("/api/v1/log/entries", a.GetEntriesHandler)
+}
+
+// GetSTHHandler serves the log's current tree head, preferring a cosigned
+// one if the witness subsystem has produced one yet.
+func (a *API) GetSTHHandler(w http.ResponseWriter, r *http.Request) {
+	if cth, ok := a.cosigner.Latest(); ok {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(cth); err != nil {
+			logging.Logger.Error(err)
+		}
+		return
+	}
+
+	sth, err := a.replicatedTreeHead()
+	if err != nil {
+		logging.Logger.Error(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(sth); err != nil {
+		logging.Logger.Error(err)
+	}
+}
+
+// GetConsistencyProofHandler serves a proof that the tree at "second" is an
+// extension of the tree at "first", verified against the root hashes the
+// caller remembers for each size (firstRootHash, secondRootHash, both
+// hex-encoded): the client's own last-seen STH and the new one it's trying
+// to confirm is consistent with it.
+func (a *API) GetConsistencyProofHandler(w http.ResponseWriter, r *http.Request) {
+	first, err := strconv.ParseUint(r.URL.Query().Get("first"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid first tree size: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	second, err := strconv.ParseUint(r.URL.Query().Get("second"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid second tree size: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	firstRootHash, err := hex.DecodeString(r.URL.Query().Get("firstRootHash"))
+	if err != nil {
+		http.Error(w, "invalid firstRootHash: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	secondRootHash, err := hex.DecodeString(r.URL.Query().Get("secondRootHash"))
+	if err != nil {
+		http.Error(w, "invalid secondRootHash: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sth, err := a.replicatedTreeHead()
+	if err != nil {
+		logging.Logger.Error(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if second > sth.TreeSize {
+		http.Error(w, fmt.Sprintf("second tree size %d not yet replicated (have %d)", second, sth.TreeSize), http.StatusConflict)
+		return
+	}
+
+	resp, err := a.logClient.getConsistencyProof(first, second, firstRootHash, secondRootHash)
+	if err != nil {
+		logging.Logger.Error(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp.getConsistencyResult.GetProof()); err != nil {
+		logging.Logger.Error(err)
+	}
+}
+
+// maxEntriesPageSize bounds how many leaves GetEntriesHandler will return
+// in a single call, so a caller can't force an unbounded range read.
+const maxEntriesPageSize = 1000
+
+// GetEntriesHandler serves count consecutive raw leaf values starting at
+// start, so a secondary can mirror this instance's leaves into its own
+// local tree.
+func (a *API) GetEntriesHandler(w http.ResponseWriter, r *http.Request) {
+	start, err := strconv.ParseInt(r.URL.Query().Get("start"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid start index: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	count, err := strconv.ParseInt(r.URL.Query().Get("count"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid count: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if count <= 0 {
+		http.Error(w, "count must be positive", http.StatusBadRequest)
+		return
+	}
+	if count > maxEntriesPageSize {
+		count = maxEntriesPageSize
+	}
+
+	leaves, err := a.logClient.getLeavesByRange(start, count)
+	if err != nil {
+		logging.Logger.Error(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	values := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		values[i] = leaf.LeafValue
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(values); err != nil {
+		logging.Logger.Error(err)
+	}
+}
@@ -0,0 +1,204 @@
+/*
+Copyright © 2020 Luke Hinds <lhinds@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/projectrekor/rekor-server/logging"
+	"github.com/spf13/viper"
+)
+
+// Role identifies whether this server instance is the authoritative
+// primary for its Trillian tree, or a read-only secondary that mirrors a
+// primary's leaves into its own local tree.
+type Role string
+
+const (
+	RolePrimary   Role = "primary"
+	RoleSecondary Role = "secondary"
+)
+
+// primaryHTTPClient bounds how long we'll wait on a hung or blackholed
+// primary, so a dead primary stalls one poll rather than the loop forever.
+var primaryHTTPClient = &http.Client{Timeout: 20 * time.Second}
+
+// ReplicationConfig mirrors the rekor_server.replication.* viper keys.
+type ReplicationConfig struct {
+	Role         Role
+	PrimaryURL   string
+	PollInterval time.Duration
+}
+
+// LoadReplicationConfig reads the replication role and settings from
+// viper, defaulting to an unreplicated primary.
+func LoadReplicationConfig() ReplicationConfig {
+	cfg := ReplicationConfig{
+		Role:         Role(viper.GetString("rekor_server.replication.role")),
+		PrimaryURL:   viper.GetString("rekor_server.replication.primary_url"),
+		PollInterval: viper.GetDuration("rekor_server.replication.poll_interval"),
+	}
+	if cfg.Role == "" {
+		cfg.Role = RolePrimary
+	}
+	if cfg.PollInterval == 0 {
+		cfg.PollInterval = 10 * time.Second
+	}
+	return cfg
+}
+
+// replicationState tracks, for a secondary, how much of the primary's tree
+// it has observed.
+type replicationState struct {
+	mu              sync.RWMutex
+	primaryTreeSize uint64
+}
+
+func (s *replicationState) setPrimaryTreeSize(size uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.primaryTreeSize = size
+}
+
+func (s *replicationState) getPrimaryTreeSize() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.primaryTreeSize
+}
+
+// replicatedTreeHead returns the tree head this instance should publish: a
+// primary publishes its own tree size, a secondary min(primaryTreeSize,
+// localTreeSize).
+func (a *API) replicatedTreeHead() (*SignedTreeHead, error) {
+	sth, err := a.logClient.treeHead()
+	if err != nil {
+		return nil, err
+	}
+	if a.replication.Role != RoleSecondary {
+		return sth, nil
+	}
+
+	if primarySize := a.replState.getPrimaryTreeSize(); primarySize < sth.TreeSize {
+		sth.TreeSize = primarySize
+	}
+	return sth, nil
+}
+
+// StartReplication launches the background mirroring loop. It is a no-op
+// for a primary.
+func (a *API) StartReplication(ctx context.Context) {
+	if a.replication.Role != RoleSecondary {
+		return
+	}
+
+	go a.pollPrimary(ctx)
+}
+
+// pollPrimary mirrors the primary's leaves into the local tree on the
+// configured interval, so replicatedTreeHead's localTreeSize actually
+// advances as the primary grows.
+func (a *API) pollPrimary(ctx context.Context) {
+	ticker := time.NewTicker(a.replication.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := a.mirrorFromPrimary(); err != nil {
+			logging.Logger.Errorf("mirroring from primary %s: %v", a.replication.PrimaryURL, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// mirrorFromPrimary pulls the primary's current tree size, then fetches
+// and re-queues into the local tree any leaves beyond what's already been
+// mirrored.
+func (a *API) mirrorFromPrimary() error {
+	primarySTH, err := fetchPrimarySTH(a.replication.PrimaryURL)
+	if err != nil {
+		return err
+	}
+	a.replState.setPrimaryTreeSize(primarySTH.TreeSize)
+
+	localRoot, err := a.logClient.root()
+	if err != nil {
+		return err
+	}
+	if primarySTH.TreeSize <= localRoot.TreeSize {
+		return nil
+	}
+
+	leaves, err := fetchLeavesFromPrimary(a.replication.PrimaryURL, int64(localRoot.TreeSize), int64(primarySTH.TreeSize-localRoot.TreeSize))
+	if err != nil {
+		return err
+	}
+
+	for _, leaf := range leaves {
+		if _, _, err := a.logClient.addLeaf(leaf, a.logClient.logID, addLeafDeadline); err != nil {
+			return fmt.Errorf("queueing mirrored leaf: %w", err)
+		}
+	}
+	return nil
+}
+
+func fetchPrimarySTH(primaryURL string) (*SignedTreeHead, error) {
+	resp, err := primaryHTTPClient.Get(fmt.Sprintf("%s/api/v1/log/sth", primaryURL))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("primary %s returned status %d", primaryURL, resp.StatusCode)
+	}
+
+	var sth SignedTreeHead
+	if err := json.NewDecoder(resp.Body).Decode(&sth); err != nil {
+		return nil, err
+	}
+	return &sth, nil
+}
+
+// fetchLeavesFromPrimary requests count raw leaf values starting at
+// startIndex from the primary's /api/v1/log/entries endpoint, which returns
+// at most maxEntriesPageSize of them per call.
+func fetchLeavesFromPrimary(primaryURL string, startIndex, count int64) ([][]byte, error) {
+	resp, err := primaryHTTPClient.Get(fmt.Sprintf("%s/api/v1/log/entries?start=%d&count=%d", primaryURL, startIndex, count))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("primary %s returned status %d", primaryURL, resp.StatusCode)
+	}
+
+	var leaves [][]byte
+	if err := json.NewDecoder(resp.Body).Decode(&leaves); err != nil {
+		return nil, err
+	}
+	return leaves, nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright © 2020 Luke Hinds <lhinds@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package submission
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// KeyPolicy decides whether a public key is permitted to submit entries
+// to the log.
+type KeyPolicy interface {
+	Allowed(pub ed25519.PublicKey) bool
+}
+
+// AllowListPolicy permits exactly the set of public keys it was
+// constructed with, keyed by hex-encoded key bytes.
+type AllowListPolicy struct {
+	keys map[string]struct{}
+}
+
+// NewAllowListPolicy builds an AllowListPolicy from a set of permitted keys.
+func NewAllowListPolicy(keys []ed25519.PublicKey) *AllowListPolicy {
+	p := &AllowListPolicy{keys: make(map[string]struct{}, len(keys))}
+	for _, k := range keys {
+		p.keys[hex.EncodeToString(k)] = struct{}{}
+	}
+	return p
+}
+
+// Allowed reports whether pub is in the allow-list.
+func (p *AllowListPolicy) Allowed(pub ed25519.PublicKey) bool {
+	_, ok := p.keys[hex.EncodeToString(pub)]
+	return ok
+}
+
+// LoadAllowListPolicy reads a namespace policy file from disk: one
+// hex-encoded ed25519 public key per line, blank lines and lines starting
+// with "#" ignored.
+func LoadAllowListPolicy(path string) (*AllowListPolicy, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening key policy %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var keys []ed25519.PublicKey
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		raw, err := hex.DecodeString(line)
+		if err != nil {
+			return nil, fmt.Errorf("parsing key policy %s: %w", path, err)
+		}
+		if len(raw) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("parsing key policy %s: key %q is not %d bytes", path, line, ed25519.PublicKeySize)
+		}
+		keys = append(keys, ed25519.PublicKey(raw))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading key policy %s: %w", path, err)
+	}
+
+	return NewAllowListPolicy(keys), nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright © 2020 Luke Hinds <lhinds@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package submission parses and verifies incoming add-entry requests.
+// Rather than logging raw, unauthenticated bytes, rekor-server requires
+// every submission to be a signed statement over a message: the submitter
+// supplies the message, a detached signature over it, and the public key
+// that produced the signature, and this package checks that signature
+// against the server's configured key policy before a leaf is ever built.
+package submission
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+)
+
+// Submission is the parsed and policy-checked form of an incoming
+// add-entry request.
+type Submission struct {
+	ShardHint uint64
+	Checksum  [sha256.Size]byte
+	Signature []byte
+	KeyHash   [sha256.Size]byte
+	PublicKey ed25519.PublicKey
+}
+
+// Parse builds a Submission from the raw fields of an add-entry request.
+// It does not verify the signature; call Verify for that.
+func Parse(message, signature, publicKey []byte, shardHint uint64) (*Submission, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public key must be %d bytes, got %d", ed25519.PublicKeySize, len(publicKey))
+	}
+
+	return &Submission{
+		ShardHint: shardHint,
+		Checksum:  sha256.Sum256(message),
+		Signature: signature,
+		KeyHash:   sha256.Sum256(publicKey),
+		PublicKey: ed25519.PublicKey(publicKey),
+	}, nil
+}
+
+// Verify checks the submission's signature over its checksum against its
+// public key, then confirms that key is allowed to submit under policy.
+func Verify(sub *Submission, policy KeyPolicy) error {
+	if !ed25519.Verify(sub.PublicKey, sub.Checksum[:], sub.Signature) {
+		return fmt.Errorf("signature verification failed")
+	}
+	if !policy.Allowed(sub.PublicKey) {
+		return fmt.Errorf("public key %x is not permitted to submit to this log", sub.KeyHash)
+	}
+	return nil
+}
+
+// canonicalLeaf is the deterministic, serialized form of a Submission that
+// gets queued to Trillian as a LogLeaf's value.
+type canonicalLeaf struct {
+	ShardHint uint64 `json:"shardHint"`
+	Checksum  string `json:"checksum"`
+	Signature string `json:"signature"`
+	KeyHash   string `json:"keyHash"`
+}
+
+// Leaf returns the canonical, deterministically-serialized leaf body for
+// sub: {ShardHint, Checksum, Signature, KeyHash}.
+func (sub *Submission) Leaf() ([]byte, error) {
+	return json.Marshal(canonicalLeaf{
+		ShardHint: sub.ShardHint,
+		Checksum:  fmt.Sprintf("%x", sub.Checksum),
+		Signature: fmt.Sprintf("%x", sub.Signature),
+		KeyHash:   fmt.Sprintf("%x", sub.KeyHash),
+	})
+}
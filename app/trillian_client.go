@@ -18,6 +18,7 @@ package app
 
 import (
 	"context"
+	"encoding/hex"
 	"fmt"
 	"time"
 
@@ -43,9 +44,17 @@ type trillianclient struct {
 }
 
 type Response struct {
-	status         codes.Code
-	getLeafResult  *trillian.GetLeavesByHashResponse
-	getProofResult *trillian.GetInclusionProofByHashResponse
+	status               codes.Code
+	getLeafResult        *trillian.GetLeavesByHashResponse
+	getProofResult       *trillian.GetInclusionProofByHashResponse
+	getConsistencyResult *trillian.GetConsistencyProofResponse
+}
+
+// SignedTreeHead is the JSON-friendly form of a Trillian LogRootV1.
+type SignedTreeHead struct {
+	TreeSize  uint64 `json:"treeSize"`
+	RootHash  string `json:"rootHash"`
+	Timestamp uint64 `json:"timestamp"`
 }
 
 func serverInstance(client trillian.TrillianLogClient, tLogID int64) *trillianclient {
@@ -70,6 +79,48 @@ func (s *trillianclient) root() (types.LogRootV1, error) {
 	return root, nil
 }
 
+// treeHead returns the log's current tree head, parsed from Trillian's
+// latest signed log root; the log root's signature is not included.
+func (s *trillianclient) treeHead() (*SignedTreeHead, error) {
+	root, err := s.root()
+	if err != nil {
+		return nil, err
+	}
+
+	return &SignedTreeHead{
+		TreeSize:  root.TreeSize,
+		RootHash:  hex.EncodeToString(root.RootHash),
+		Timestamp: root.TimestampNanos,
+	}, nil
+}
+
+// getConsistencyProof fetches a proof that the tree at size `second`
+// contains everything that was in the tree at size `first`, and verifies
+// it against the two given root hashes.
+func (s *trillianclient) getConsistencyProof(first, second uint64, firstRootHash, secondRootHash []byte) (*Response, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	resp, err := s.client.GetConsistencyProof(ctx, &trillian.GetConsistencyProofRequest{
+		LogId:          s.logID,
+		FirstTreeSize:  int64(first),
+		SecondTreeSize: int64(second),
+	})
+	if err != nil {
+		return &Response{}, err
+	}
+
+	v := merkle.NewLogVerifier(rfc6962.DefaultHasher)
+	if err := v.VerifyConsistencyProof(int64(first), int64(second), firstRootHash, secondRootHash, resp.Proof.GetHashes()); err != nil {
+		return &Response{}, err
+	}
+
+	return &Response{
+		status:               status.Code(err),
+		getConsistencyResult: resp,
+	}, nil
+}
+
 func (s *trillianclient) getProof(byteValue []byte, tLogID int64) (*Response, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 	defer cancel()
@@ -111,7 +162,13 @@ func (s *trillianclient) getProof(byteValue []byte, tLogID int64) (*Response, er
 	}, nil
 }
 
-func (s *trillianclient) addLeaf(byteValue []byte, tLogID int64) (*Response, error) {
+// addLeaf queues byteValue for inclusion in the tree, then blocks (polling
+// root() up to deadline) until it has actually been sequenced, returning
+// its inclusion proof. A duplicate submission (codes.AlreadyExists from
+// QueueLeaf) is treated as success, since resubmitting the same leaf is
+// expected to be idempotent; codes.NotFound from the proof lookup just
+// means the leaf is queued but not yet sequenced, so polling continues.
+func (s *trillianclient) addLeaf(byteValue []byte, tLogID int64, deadline time.Duration) (sequenced bool, proof *trillian.Proof, err error) {
 	leaf := &trillian.LogLeaf{
 		LeafValue: byteValue,
 	}
@@ -119,16 +176,53 @@ func (s *trillianclient) addLeaf(byteValue []byte, tLogID int64) (*Response, err
 		LogId: tLogID,
 		Leaf:  leaf,
 	}
-	resp, err := s.client.QueueLeaf(context.Background(), rqst)
-	if err != nil {
-		fmt.Println(err)
+
+	if _, err := s.client.QueueLeaf(context.Background(), rqst); err != nil && status.Code(err) != codes.AlreadyExists {
+		return false, nil, fmt.Errorf("queueing leaf: %w", err)
 	}
 
-	resultCode := codes.Code(resp.QueuedLeaf.GetStatus().GetCode())
+	hasher := rfc6962.DefaultHasher
+	leafHash := hasher.HashLeaf(byteValue)
 
-	return &Response{
-		status: resultCode,
-	}, nil
+	ctx, cancel := context.WithTimeout(context.Background(), deadline)
+	defer cancel()
+
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		root, err := s.root()
+		if err != nil {
+			return false, nil, err
+		}
+
+		proofResp, proofErr := s.client.GetInclusionProofByHash(ctx, &trillian.GetInclusionProofByHashRequest{
+			LogId:    tLogID,
+			LeafHash: leafHash,
+			TreeSize: int64(root.TreeSize),
+		})
+		switch status.Code(proofErr) {
+		case codes.OK:
+			if len(proofResp.Proof) > 0 {
+				p := proofResp.Proof[0]
+				v := merkle.NewLogVerifier(rfc6962.DefaultHasher)
+				if err := v.VerifyInclusionProof(p.LeafIndex, int64(root.TreeSize), p.GetHashes(), root.RootHash, leafHash); err != nil {
+					return false, nil, fmt.Errorf("verifying inclusion proof: %w", err)
+				}
+				return true, p, nil
+			}
+		case codes.NotFound:
+			// queued but not yet sequenced at this tree size; keep polling
+		default:
+			return false, nil, proofErr
+		}
+
+		select {
+		case <-ctx.Done():
+			return false, nil, fmt.Errorf("waiting for leaf to be sequenced: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
 }
 
 func (s *trillianclient) getLeaf(byteValue []byte, tlog_id int64) (*Response, error) {
@@ -142,7 +236,7 @@ func (s *trillianclient) getLeaf(byteValue []byte, tlog_id int64) (*Response, er
 
 	resp, err := s.client.GetLeavesByHash(context.Background(), rqst)
 	if err != nil {
-		logging.Logger.Fatal(err)
+		return &Response{}, err
 	}
 
 	return &Response{
@@ -151,6 +245,23 @@ func (s *trillianclient) getLeaf(byteValue []byte, tlog_id int64) (*Response, er
 	}, nil
 }
 
+// getLeavesByRange fetches count consecutive leaves starting at startIndex,
+// for a secondary mirroring a primary's tree.
+func (s *trillianclient) getLeavesByRange(startIndex, count int64) ([]*trillian.LogLeaf, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	resp, err := s.client.GetLeavesByRange(ctx, &trillian.GetLeavesByRangeRequest{
+		LogId:      s.logID,
+		StartIndex: startIndex,
+		Count:      count,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Leaves, nil
+}
+
 func createAndInitTree(ctx context.Context, adminClient trillian.TrillianAdminClient, logClient trillian.TrillianLogClient) (*trillian.Tree, error) {
 	// First look for and use an existing tree
 	trees, err := adminClient.ListTrees(ctx, &trillian.ListTreesRequest{})
@@ -0,0 +1,51 @@
+/*
+Copyright © 2020 Luke Hinds <lhinds@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package witness
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
+// LoadWitnessesFromConfig reads the rekor_server.witnesses list (each
+// entry a {url, pubkey} pair, pubkey hex-encoded) from viper.
+func LoadWitnessesFromConfig() ([]Witness, error) {
+	var entries []struct {
+		URL    string `mapstructure:"url"`
+		PubKey string `mapstructure:"pubkey"`
+	}
+	if err := viper.UnmarshalKey("rekor_server.witnesses", &entries); err != nil {
+		return nil, fmt.Errorf("parsing rekor_server.witnesses: %w", err)
+	}
+
+	witnesses := make([]Witness, 0, len(entries))
+	for _, e := range entries {
+		raw, err := hex.DecodeString(e.PubKey)
+		if err != nil {
+			return nil, fmt.Errorf("parsing witness %s pubkey: %w", e.URL, err)
+		}
+		if len(raw) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("witness %s pubkey must be %d bytes, got %d", e.URL, ed25519.PublicKeySize, len(raw))
+		}
+
+		witnesses = append(witnesses, Witness{URL: e.URL, PubKey: ed25519.PublicKey(raw)})
+	}
+	return witnesses, nil
+}
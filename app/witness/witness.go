@@ -0,0 +1,175 @@
+/*
+Copyright © 2020 Luke Hinds <lhinds@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package witness signs the log's current tree head and collects
+// cosignatures from a configured set of external witnesses.
+package witness
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/projectrekor/rekor-server/logging"
+)
+
+// TreeHeadV1 is the signable representation of a tree head.
+type TreeHeadV1 struct {
+	TreeSize  uint64 `json:"treeSize"`
+	RootHash  string `json:"rootHash"`
+	Timestamp uint64 `json:"timestamp"`
+}
+
+func (th TreeHeadV1) signedMessage() []byte {
+	return []byte(fmt.Sprintf("rekor-server/v1/tree-head\n%d\n%s\n%d", th.TreeSize, th.RootHash, th.Timestamp))
+}
+
+// witnessHTTPClient bounds how long we'll wait on an unresponsive witness,
+// so one dead witness can't wedge the whole cosigning loop.
+var witnessHTTPClient = &http.Client{Timeout: 20 * time.Second}
+
+// CosignedTreeHead is a tree head together with the log's own signature
+// and any witness signatures collected over it.
+type CosignedTreeHead struct {
+	TreeHeadV1
+	LogSignature      []byte            `json:"logSignature"`
+	WitnessSignatures map[string][]byte `json:"witnessSignatures"`
+}
+
+// Witness is an external cosigner, identified by the endpoint it cosigns
+// at and the key it signs with.
+type Witness struct {
+	URL    string
+	PubKey ed25519.PublicKey
+}
+
+// Cosigner signs the log's current tree head and collects matching
+// cosignatures from its configured witnesses.
+type Cosigner struct {
+	key       ed25519.PrivateKey
+	witnesses []Witness
+	treeHead  func() (TreeHeadV1, error)
+
+	mu     sync.RWMutex
+	latest *CosignedTreeHead
+}
+
+// NewCosigner builds a Cosigner that signs with key and cosigns with
+// witnesses, pulling the tree head to sign from treeHead.
+func NewCosigner(key ed25519.PrivateKey, witnesses []Witness, treeHead func() (TreeHeadV1, error)) *Cosigner {
+	return &Cosigner{
+		key:       key,
+		witnesses: witnesses,
+		treeHead:  treeHead,
+	}
+}
+
+// Latest returns the most recently produced cosigned tree head, if one has
+// been produced yet.
+func (c *Cosigner) Latest() (*CosignedTreeHead, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.latest == nil {
+		return nil, false
+	}
+	return c.latest, true
+}
+
+// Start runs the cosigning loop on the given interval until ctx is done.
+func (c *Cosigner) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.cosignOnce(); err != nil {
+			logging.Logger.Errorf("cosigning tree head: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Cosigner) cosignOnce() error {
+	th, err := c.treeHead()
+	if err != nil {
+		return err
+	}
+
+	logSig := ed25519.Sign(c.key, th.signedMessage())
+
+	cth := &CosignedTreeHead{
+		TreeHeadV1:        th,
+		LogSignature:      logSig,
+		WitnessSignatures: make(map[string][]byte, len(c.witnesses)),
+	}
+
+	for _, w := range c.witnesses {
+		sig, err := requestCosignature(w, th, logSig)
+		if err != nil {
+			logging.Logger.Errorf("requesting cosignature from witness %s: %v", w.URL, err)
+			continue
+		}
+		cth.WitnessSignatures[w.URL] = sig
+	}
+
+	c.mu.Lock()
+	c.latest = cth
+	c.mu.Unlock()
+	return nil
+}
+
+// requestCosignature POSTs th to w and verifies the returned signature
+// against w's configured public key before trusting it.
+func requestCosignature(w Witness, th TreeHeadV1, logSig []byte) ([]byte, error) {
+	body, err := json.Marshal(struct {
+		TreeHeadV1
+		LogSignature []byte `json:"logSignature"`
+	}{th, logSig})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := witnessHTTPClient.Post(w.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("witness returned status %d", resp.StatusCode)
+	}
+
+	var cosig struct {
+		Signature []byte `json:"signature"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&cosig); err != nil {
+		return nil, err
+	}
+
+	if !ed25519.Verify(w.PubKey, th.signedMessage(), cosig.Signature) {
+		return nil, fmt.Errorf("cosignature failed verification")
+	}
+	return cosig.Signature, nil
+}